@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openbankit/horizon/ingest"
+	"github.com/openbankit/horizon/log"
+)
+
+// dbCmd is the parent of all `horizon db ...` subcommands operating on
+// horizon's history database.
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "commands to manage horizon's history database",
+}
+
+// dbReingestCmd is the parent of the reingest subcommands.
+var dbReingestCmd = &cobra.Command{
+	Use:   "reingest",
+	Short: "reingests historical data",
+}
+
+var (
+	reingestParallelWorkers int
+	reingestParallelJobSize int32
+
+	reingestDisableAssetStats        bool
+	reingestDisableTradeAggregations bool
+	reingestDisableEffects           bool
+	reingestSkipCursorUpdate         bool
+)
+
+// addConfigFlags registers one flag per ingest.Config field (see its doc
+// comment for what operators use these for and which API endpoints each one
+// disables).
+func addConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(
+		&reingestDisableAssetStats, "disable-asset-stats", false,
+		"skip recomputing asset stats; disables the stats fields on /assets",
+	)
+	cmd.Flags().BoolVar(
+		&reingestDisableTradeAggregations, "disable-trade-aggregations", false,
+		"skip rebuilding trade aggregation buckets; disables /trade_aggregations",
+	)
+	cmd.Flags().BoolVar(
+		&reingestDisableEffects, "disable-effects", false,
+		"skip writing history_effects rows; disables /effects and embedded effects",
+	)
+	cmd.Flags().BoolVar(
+		&reingestSkipCursorUpdate, "skip-cursor-update", false,
+		"leave horizon's cursor on stellar-core untouched",
+	)
+}
+
+func reingestConfig() ingest.Config {
+	return ingest.Config{
+		DisableAssetStats:        reingestDisableAssetStats,
+		DisableTradeAggregations: reingestDisableTradeAggregations,
+		DisableEffects:           reingestDisableEffects,
+		SkipCursorUpdate:         reingestSkipCursorUpdate,
+	}
+}
+
+var dbReingestRangeCmd = &cobra.Command{
+	Use:   "range <start> <end>",
+	Short: "reingests historical data for a range of ledgers, inclusive",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			return err
+		}
+		end, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return err
+		}
+
+		app, err := NewApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		cfg := reingestConfig()
+
+		if reingestParallelWorkers > 0 {
+			n, err := app.Ingester().ReingestRangeParallelConfig(
+				int32(start), int32(end), reingestParallelWorkers, reingestParallelJobSize, cfg,
+			)
+			log.Infof("reingested %d ledgers", n)
+			return err
+		}
+
+		n, err := app.Ingester().ReingestRangeConfig(int32(start), int32(end), cfg)
+		log.Infof("reingested %d ledgers", n)
+		return err
+	},
+}
+
+var dbReingestAllCmd = &cobra.Command{
+	Use:   "all",
+	Short: "reingests all historical data",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := NewApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		n, err := app.Ingester().ReingestAllConfig(reingestConfig())
+		log.Infof("reingested %d ledgers", n)
+		return err
+	},
+}
+
+var dbReingestOutdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "reingests ledgers ingested by an older version of horizon",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := NewApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		n, err := app.Ingester().ReingestOutdatedConfig(reingestConfig())
+		log.Infof("reingested %d ledgers", n)
+		return err
+	},
+}
+
+func init() {
+	dbReingestRangeCmd.Flags().IntVar(
+		&reingestParallelWorkers, "parallel-workers", 0,
+		"reingest this range using N workers in parallel instead of serially",
+	)
+	dbReingestRangeCmd.Flags().Int32Var(
+		&reingestParallelJobSize, "parallel-job-size", ingest.DefaultParallelJobSize,
+		"number of ledgers handed to each parallel worker (only used with --parallel-workers)",
+	)
+	addConfigFlags(dbReingestRangeCmd)
+	addConfigFlags(dbReingestAllCmd)
+	addConfigFlags(dbReingestOutdatedCmd)
+
+	dbReingestCmd.AddCommand(dbReingestRangeCmd, dbReingestAllCmd, dbReingestOutdatedCmd)
+	dbCmd.AddCommand(dbReingestCmd)
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// dbDetectGapsCmd prints a `horizon db reingest range` line for every gap
+// System.DetectGaps finds, so operators can pipe the output straight into a
+// shell loop to backfill them.
+var dbDetectGapsCmd = &cobra.Command{
+	Use:   "detect-gaps",
+	Short: "finds gaps in horizon's history and prints the commands to fill them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := NewApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		gaps, err := app.Ingester().DetectGaps()
+		if err != nil {
+			return err
+		}
+
+		for _, gap := range gaps {
+			fmt.Printf("horizon db reingest range %d %d\n", gap.StartSequence, gap.EndSequence)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbDetectGapsCmd)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
+
+	"github.com/openbankit/horizon/log"
+)
+
+var adminAddr string
+
+// serveCmd starts horizon's ingestion poller and its admin HTTP server. The
+// admin server is kept separate from the public API router since it exposes
+// internal operational endpoints (currently just /metrics) that shouldn't be
+// reachable from the public API's listener.
+//
+// On SIGINT/SIGTERM it calls app.Close(), which cancels the ingester's
+// context and waits for any in-flight session to observe that and return at
+// the next ledger boundary, and only then shuts the admin server down, so
+// the command does not return (and the process exit) while in-flight DB work
+// is still running.
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "starts horizon's ingestion poller and admin HTTP server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := NewApp()
+		if err != nil {
+			return err
+		}
+
+		app.Ingester().Start()
+
+		admin := mux.NewRouter()
+		app.Ingester().RegisterAdminRoutes(admin)
+		server := &http.Server{Addr: adminAddr, Handler: admin}
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-sig
+			log.Info("received shutdown signal, stopping horizon")
+			app.Close()
+			server.Shutdown(context.Background())
+		}()
+
+		log.WithField("addr", adminAddr).Info("serving admin endpoints")
+		err = server.ListenAndServe()
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(
+		&adminAddr, "admin-addr", ":6060",
+		"address the admin HTTP server (currently just /metrics) listens on",
+	)
+}
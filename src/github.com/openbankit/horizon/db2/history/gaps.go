@@ -0,0 +1,44 @@
+package history
+
+// LedgerGap represents a contiguous range of missing ledger sequences in the
+// history_ledgers table, inclusive on both ends.
+type LedgerGap struct {
+	StartSequence int32 `db:"start_sequence"`
+	EndSequence   int32 `db:"end_sequence"`
+}
+
+// ledgerGapsSQL finds every pair of adjacent rows in history_ledgers whose
+// sequence numbers are not consecutive and reports the sequence range that is
+// missing between them.
+const ledgerGapsSQL = `
+WITH bounds AS (
+	SELECT
+		sequence,
+		LEAD(sequence) OVER (ORDER BY sequence) AS next_sequence
+	FROM history_ledgers
+)
+SELECT
+	sequence + 1 AS start_sequence,
+	next_sequence - 1 AS end_sequence
+FROM bounds
+WHERE next_sequence IS NOT NULL
+AND next_sequence - sequence > 1
+ORDER BY start_sequence ASC
+`
+
+// LedgerGaps loads every gap in the sequence of ingested ledgers found in
+// history_ledgers. It only reports gaps between the oldest and newest
+// ingested ledgers; callers that also need to know about a gap relative to
+// stellar-core's latest ledger should cross-check the result against
+// `core.Q.LatestLedger` themselves, since this query has no way to know what
+// "caught up" means.
+func (q *Q) LedgerGaps(dest *[]LedgerGap) error {
+	return q.Select(dest, ledgerGapsSQL)
+}
+
+// OldestLedger loads the lowest sequence present in history_ledgers. It is
+// used alongside LatestLedger to detect a leading gap, i.e. ledgers that were
+// never ingested before horizon started tracking history.
+func (q *Q) OldestLedger(dest *int32) error {
+	return q.GetRaw(dest, "SELECT COALESCE(MIN(sequence), 0) FROM history_ledgers")
+}
@@ -0,0 +1,69 @@
+package history_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openbankit/horizon/db2/history"
+	"github.com/openbankit/horizon/test"
+)
+
+func TestLedgerGaps(t *testing.T) {
+	tt := test.Start(t).Scenario("base")
+	defer tt.Finish()
+
+	q := &history.Q{Repo: tt.HorizonRepo()}
+
+	insertLedger := func(seq int32) {
+		_, err := tt.HorizonRepo().Exec(
+			"INSERT INTO history_ledgers (sequence, closed_at) VALUES (?, NOW())", seq,
+		)
+		require.NoError(t, err)
+	}
+
+	t.Run("no gaps", func(t *testing.T) {
+		tt.ResetHorizonDB()
+		for _, seq := range []int32{1, 2, 3} {
+			insertLedger(seq)
+		}
+
+		var gaps []history.LedgerGap
+		require.NoError(t, q.LedgerGaps(&gaps))
+		assert.Empty(t, gaps)
+	})
+
+	t.Run("single-ledger gap", func(t *testing.T) {
+		tt.ResetHorizonDB()
+		for _, seq := range []int32{1, 2, 4, 5} {
+			insertLedger(seq)
+		}
+
+		var gaps []history.LedgerGap
+		require.NoError(t, q.LedgerGaps(&gaps))
+		assert.Equal(t, []history.LedgerGap{{StartSequence: 3, EndSequence: 3}}, gaps)
+	})
+
+	t.Run("multi-ledger gap", func(t *testing.T) {
+		tt.ResetHorizonDB()
+		for _, seq := range []int32{1, 2, 7, 8} {
+			insertLedger(seq)
+		}
+
+		var gaps []history.LedgerGap
+		require.NoError(t, q.LedgerGaps(&gaps))
+		assert.Equal(t, []history.LedgerGap{{StartSequence: 3, EndSequence: 6}}, gaps)
+	})
+
+	t.Run("oldest ledger for leading-gap detection", func(t *testing.T) {
+		tt.ResetHorizonDB()
+		for _, seq := range []int32{5, 6, 7} {
+			insertLedger(seq)
+		}
+
+		var oldest int32
+		require.NoError(t, q.OldestLedger(&oldest))
+		assert.EqualValues(t, 5, oldest)
+	})
+}
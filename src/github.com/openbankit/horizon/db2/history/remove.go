@@ -0,0 +1,77 @@
+package history
+
+// RemovalSummary reports how many rows were deleted from each history table
+// by RemoveLedgerRange, so callers can log or verify the scope of the
+// cleanup before reingesting.
+type RemovalSummary struct {
+	Ledgers      int64
+	Transactions int64
+	Operations   int64
+	Effects      int64
+	Trades       int64
+	AssetStats   int64
+}
+
+// removalStatements lists, in dependency order, the delete statements run by
+// RemoveLedgerRange. Child rows (operations, effects, trades, asset stats
+// increments, ...) are removed before their parent ledger row so that a
+// mid-transaction failure never leaves orphaned history behind.
+var removalStatements = []struct {
+	table string
+	sql   string
+}{
+	{"effects", "DELETE FROM history_effects WHERE history_operation_id IN (SELECT id FROM history_operations WHERE transaction_id IN (SELECT id FROM history_transactions WHERE ledger_sequence BETWEEN ? AND ?))"},
+	{"trades", "DELETE FROM history_trades WHERE ledger_closed_at IN (SELECT closed_at FROM history_ledgers WHERE sequence BETWEEN ? AND ?)"},
+	{"asset_stats", "DELETE FROM history_assets_stats_deltas WHERE ledger_sequence BETWEEN ? AND ?"},
+	{"operations", "DELETE FROM history_operations WHERE transaction_id IN (SELECT id FROM history_transactions WHERE ledger_sequence BETWEEN ? AND ?)"},
+	{"transactions", "DELETE FROM history_transactions WHERE ledger_sequence BETWEEN ? AND ?"},
+	{"ledgers", "DELETE FROM history_ledgers WHERE sequence BETWEEN ? AND ?"},
+}
+
+// RemoveLedgerRange deletes every history row keyed by a ledger sequence in
+// [start, end], inclusive, across all history tables, including the
+// account/asset stats increments recorded in history_assets_stats_deltas. It
+// runs inside a single transaction so a reingest of an overlapping range
+// never observes a partially-cleared history.
+//
+// This exists because a session's inserts assume a clean range; when the
+// requested range overlaps ledgers ingested by a prior run (checkpoint
+// restarts, parallel workers) those inserts fail on unique constraints
+// instead of overwriting, so callers run this over the whole range before
+// starting a session.
+func (q *Q) RemoveLedgerRange(start, end int32) (RemovalSummary, error) {
+	var summary RemovalSummary
+
+	err := q.Repo.Transaction(func() error {
+		for _, stmt := range removalStatements {
+			result, err := q.Repo.Exec(stmt.sql, start, end)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			switch stmt.table {
+			case "ledgers":
+				summary.Ledgers = affected
+			case "transactions":
+				summary.Transactions = affected
+			case "operations":
+				summary.Operations = affected
+			case "effects":
+				summary.Effects = affected
+			case "trades":
+				summary.Trades = affected
+			case "asset_stats":
+				summary.AssetStats = affected
+			}
+		}
+
+		return nil
+	})
+
+	return summary, err
+}
@@ -0,0 +1,19 @@
+package ingest
+
+import "github.com/openbankit/horizon/ingest/session"
+
+// Config controls which of the more expensive ingestion sub-processors run
+// during a session. It lets operators carve ingestion work across replicas,
+// for example running a fast "ledgers + transactions only" backfill on one
+// box and a targeted reingest that fills in the heavier tables on another.
+//
+// Note that disabling a flag here also disables the horizon API endpoints
+// that depend on the data it produces: DisableAssetStats turns off the
+// assets endpoint's stats fields, DisableTradeAggregations turns off
+// `/trade_aggregations`, and DisableEffects turns off `/effects` and the
+// effects embedded in operation responses.
+//
+// Config is a type alias of session.Config: the session package is the one
+// that actually consults these flags, and ingest's callers use this alias so
+// they don't need to import the session package directly.
+type Config = session.Config
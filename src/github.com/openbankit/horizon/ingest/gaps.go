@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"github.com/openbankit/horizon/db2/core"
+	"github.com/openbankit/horizon/db2/history"
+)
+
+// DetectGaps finds every range of ledgers that is missing from the history
+// database: the interior gaps reported by `history.Q.LedgerGaps`, a leading
+// gap between ledger 1 and the oldest ingested ledger, and a trailing gap
+// between the latest ingested history ledger and stellar-core's latest
+// ledger.
+func (i *System) DetectGaps() ([]history.LedgerGap, error) {
+	hq := &history.Q{Repo: i.HorizonDB}
+	cq := &core.Q{Repo: i.CoreDB}
+
+	var gaps []history.LedgerGap
+	err := hq.LedgerGaps(&gaps)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldestHistory int32
+	err = hq.OldestLedger(&oldestHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	if oldestHistory > 1 {
+		gaps = append([]history.LedgerGap{{
+			StartSequence: 1,
+			EndSequence:   oldestHistory - 1,
+		}}, gaps...)
+	}
+
+	var latestHistory int32
+	err = hq.LatestLedger(&latestHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	var latestCore int32
+	err = cq.LatestLedger(&latestCore)
+	if err != nil {
+		return nil, err
+	}
+
+	if latestCore > latestHistory {
+		gaps = append(gaps, history.LedgerGap{
+			StartSequence: latestHistory + 1,
+			EndSequence:   latestCore,
+		})
+	}
+
+	return gaps, nil
+}
@@ -0,0 +1,77 @@
+package ingest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openbankit/horizon/db2/history"
+	"github.com/openbankit/horizon/ingest"
+	"github.com/openbankit/horizon/test"
+)
+
+func TestSystemDetectGaps(t *testing.T) {
+	tt := test.Start(t).Scenario("base")
+	defer tt.Finish()
+
+	system := &ingest.System{HorizonDB: tt.HorizonRepo(), CoreDB: tt.CoreRepo()}
+
+	insertCoreLedger := func(seq int32) {
+		_, err := tt.CoreRepo().Exec(
+			"INSERT INTO ledgerheaders (ledgerseq, closetime) VALUES (?, NOW())", seq,
+		)
+		require.NoError(t, err)
+	}
+	insertHistoryLedger := func(seq int32) {
+		_, err := tt.HorizonRepo().Exec(
+			"INSERT INTO history_ledgers (sequence, closed_at) VALUES (?, NOW())", seq,
+		)
+		require.NoError(t, err)
+	}
+
+	tt.ResetHorizonDB()
+	tt.ResetCoreDB()
+
+	// core is at ledger 10, history has ingested 1-3 and 7-8: a leading gap
+	// is not expected (history starts at 1), an interior gap at [4,6], and a
+	// trailing gap at [9,10].
+	for _, seq := range []int32{1, 2, 3, 7, 8} {
+		insertHistoryLedger(seq)
+	}
+	for seq := int32(1); seq <= 10; seq++ {
+		insertCoreLedger(seq)
+	}
+
+	gaps, err := system.DetectGaps()
+	require.NoError(t, err)
+	assert.Equal(t, []history.LedgerGap{
+		{StartSequence: 4, EndSequence: 6},
+		{StartSequence: 9, EndSequence: 10},
+	}, gaps)
+}
+
+func TestSystemDetectGapsLeading(t *testing.T) {
+	tt := test.Start(t).Scenario("base")
+	defer tt.Finish()
+
+	system := &ingest.System{HorizonDB: tt.HorizonRepo(), CoreDB: tt.CoreRepo()}
+
+	tt.ResetHorizonDB()
+	tt.ResetCoreDB()
+
+	_, err := tt.HorizonRepo().Exec(
+		"INSERT INTO history_ledgers (sequence, closed_at) VALUES (?, NOW())", int32(5),
+	)
+	require.NoError(t, err)
+	_, err = tt.CoreRepo().Exec(
+		"INSERT INTO ledgerheaders (ledgerseq, closetime) VALUES (?, NOW())", int32(5),
+	)
+	require.NoError(t, err)
+
+	gaps, err := system.DetectGaps()
+	require.NoError(t, err)
+	assert.Equal(t, []history.LedgerGap{
+		{StartSequence: 1, EndSequence: 4},
+	}, gaps)
+}
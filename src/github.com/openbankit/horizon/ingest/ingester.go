@@ -1,6 +1,11 @@
 package ingest
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/openbankit/horizon/db2/core"
 	"github.com/openbankit/horizon/db2/history"
 	"github.com/openbankit/horizon/errors"
@@ -8,23 +13,55 @@ import (
 	"github.com/openbankit/horizon/log"
 )
 
-// Close causes the ingester to shut down.
+// Close causes the ingester to shut down. It cancels the context set by
+// Start/StartWithContext, which the poller and any reingest running on that
+// context observe, then blocks until the poller and every in-flight reingest
+// have returned. A reingest running on a context of its own (or on the
+// background context, when Start was never called) is waited for, not
+// canceled.
 func (i *System) Close() {
 	log.Info("canceling ingestion poller")
 	i.tick.Stop()
+	if i.cancel != nil {
+		i.cancel()
+	}
+	i.wg.Wait()
+}
+
+// context returns the system's lifetime context, falling back to a
+// background context for callers that trigger a one-off reingest without
+// ever calling Start()/StartWithContext.
+func (i *System) context() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+	return i.ctx
 }
 
 // ReingestAll re-ingests all ledgers
 func (i *System) ReingestAll() (int, error) {
+	return i.ReingestAllConfig(i.Config)
+}
+
+// ReingestAllConfig is like ReingestAll, but runs the reingest with cfg
+// instead of the system's default Config, e.g. to run a fast backfill that
+// disables the heavier sub-processors.
+func (i *System) ReingestAllConfig(cfg Config) (int, error) {
 	err := i.updateLedgerState()
 	if err != nil {
 		return 0, err
 	}
-	return i.ReingestRange(1, i.coreSequence)
+	return i.ReingestRangeConfig(1, i.coreSequence, cfg)
 }
 
 // ReingestOutdated finds old ledgers and reimports them.
 func (i *System) ReingestOutdated() (n int, err error) {
+	return i.ReingestOutdatedConfig(i.Config)
+}
+
+// ReingestOutdatedConfig is like ReingestOutdated, but runs each reingested
+// range with cfg instead of the system's default Config.
+func (i *System) ReingestOutdatedConfig(cfg Config) (n int, err error) {
 	q := history.Q{Repo: i.HorizonDB}
 
 	// NOTE: this loop will never terminate if some bug were cause a ledger
@@ -47,7 +84,7 @@ func (i *System) ReingestOutdated() (n int, err error) {
 
 		var start, end int32
 		flush := func() error {
-			ingested, ferr := i.ReingestRange(start, end)
+			ingested, ferr := i.ReingestRangeConfig(start, end, cfg)
 
 			if ferr != nil {
 				return ferr
@@ -86,19 +123,79 @@ func (i *System) ReingestOutdated() (n int, err error) {
 	}
 }
 
+// ErrReingestRangeConflict is returned by ReingestRange when the requested
+// range overlaps ledgers that live ingestion is about to write, since
+// reingesting them concurrently would race with runOnce.
+type ErrReingestRangeConflict struct {
+	Ledger int32
+}
+
+// polling reports whether the background poller started by
+// Start/StartWithContext is still running: only then can a reingest
+// actually race with runOnce over ledgers it hasn't ingested yet. A
+// one-off reingest run before Start (or after Close) is free to cover any
+// range, including one that reaches all the way up to stellar-core's
+// latest ledger, which is the normal case for ReingestAll.
+func (i *System) polling() bool {
+	return i.cancel != nil && i.ctx != nil && i.ctx.Err() == nil
+}
+
+func (e ErrReingestRangeConflict) Error() string {
+	return fmt.Sprintf(
+		"reingest range extends past horizon's most recently ingested ledger (%d)",
+		e.Ledger,
+	)
+}
+
 // ReingestRange reingests a range of ledgers, from `start` to `end`, inclusive.
 func (i *System) ReingestRange(start, end int32) (int, error) {
+	return i.ReingestRangeConfig(start, end, i.Config)
+}
+
+// ReingestRangeConfig is like ReingestRange, but runs the session with cfg
+// instead of the system's default Config (see Config's doc comment for what
+// an operator can do with that).
+func (i *System) ReingestRangeConfig(start, end int32, cfg Config) (int, error) {
+	return i.ReingestRangeContext(i.context(), start, end, cfg)
+}
+
+// ReingestRangeContext reingests a range of ledgers, from `start` to `end`,
+// inclusive, observing ctx cancellation at each ledger boundary so a long
+// reingest can be stopped cleanly instead of running to completion.
+func (i *System) ReingestRangeContext(ctx context.Context, start, end int32, cfg Config) (int, error) {
+	i.wg.Add(1)
+	defer i.wg.Done()
+
+	err := i.updateLedgerState()
+	if err != nil {
+		return 0, err
+	}
+
+	if i.polling() && end > i.historySequence {
+		return 0, ErrReingestRangeConflict{Ledger: i.historySequence}
+	}
+
+	hq := history.Q{Repo: i.HorizonDB}
+	_, err = hq.RemoveLedgerRange(start, end)
+	if err != nil {
+		return 0, err
+	}
+
 	is := session.NewSession(
-		i.historySequence+1,
-		i.coreSequence,
+		ctx,
+		start,
+		end,
 		i.HorizonDB,
 		i.CoreDB,
 		i.HistoryAccountCache,
 		i.Metrics,
 		CurrentVersion,
+		cfg,
 	)
-	is.ClearExisting = true
-	err := is.Run()
+	err = is.Run()
+	if err == context.Canceled {
+		return is.Ingested, context.Canceled
+	}
 	return is.Ingested, err
 }
 
@@ -111,13 +208,28 @@ func (i *System) ReingestSingle(sequence int32) error {
 // Start causes the ingester to begin polling the stellar-core database for now
 // ledgers and ingesting data into the horizon database.
 func (i *System) Start() {
+	i.StartWithContext(context.Background())
+}
+
+// StartWithContext is like Start, but ties the poller's lifetime to ctx in
+// addition to Close: whichever fires first stops the poller.
+func (i *System) StartWithContext(ctx context.Context) {
+	i.ctx, i.cancel = context.WithCancel(ctx)
+	i.wg.Add(1)
 	go i.run()
 }
 
 func (i *System) run() {
-	for _ = range i.tick.C {
-		log.Debug("ticking ingester")
-		i.runOnce()
+	defer i.wg.Done()
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-i.tick.C:
+			log.Debug("ticking ingester")
+			i.runOnce()
+		}
 	}
 }
 
@@ -128,6 +240,7 @@ func (i *System) runOnce() {
 	defer func() {
 		if rec := recover(); rec != nil {
 			err := errors.FromPanic(rec)
+			ingestErrorsTotal.WithLabelValues("panic").Inc()
 			log.WithStack(err).Errorf("import session panicked: %s", err)
 			errors.ReportToSentry(err, nil)
 		}
@@ -138,9 +251,14 @@ func (i *System) runOnce() {
 	// 3. if any were imported, go to 1
 	for {
 		// 1.
+		if i.ctx.Err() != nil {
+			return
+		}
+
 		err := i.updateLedgerState()
 
 		if err != nil {
+			ingestErrorsTotal.WithLabelValues("update_ledger_state").Inc()
 			log.Errorf("could not load ledger state: %s", err)
 			return
 		}
@@ -150,6 +268,7 @@ func (i *System) runOnce() {
 			return
 		}
 		is := session.NewSession(
+			i.ctx,
 			i.historySequence+1,
 			i.coreSequence,
 			i.HorizonDB,
@@ -157,11 +276,22 @@ func (i *System) runOnce() {
 			i.HistoryAccountCache,
 			i.Metrics,
 			CurrentVersion,
+			i.Config,
 		)
 
+		timer := prometheus.NewTimer(ingestSessionDuration)
 		err = is.Run()
+		timer.ObserveDuration()
+
+		ingestLedgersTotal.Add(float64(is.Ingested))
+
+		if err == context.Canceled {
+			log.Info("import session canceled")
+			return
+		}
 
 		if err != nil {
+			ingestErrorsTotal.WithLabelValues("session_run").Inc()
 			log.WithStack(err).Errorf("import session failed: %s", err)
 			return
 		}
@@ -188,5 +318,9 @@ func (i *System) updateLedgerState() error {
 		return err
 	}
 
+	ingestCoreLatestLedger.Set(float64(i.coreSequence))
+	ingestHistoryLatestLedger.Set(float64(i.historySequence))
+	ingestLagLedgers.Set(float64(i.coreSequence - i.historySequence))
+
 	return nil
 }
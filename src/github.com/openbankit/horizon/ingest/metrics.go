@@ -0,0 +1,66 @@
+package ingest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	ingestCoreLatestLedger = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "horizon_ingest_core_latest_ledger",
+		Help: "Latest ledger sequence known to stellar-core.",
+	})
+	ingestHistoryLatestLedger = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "horizon_ingest_history_latest_ledger",
+		Help: "Latest ledger sequence ingested into horizon's history database.",
+	})
+	ingestLagLedgers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "horizon_ingest_lag_ledgers",
+		Help: "Number of ledgers horizon's history database is behind stellar-core.",
+	})
+	ingestSessionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "horizon_ingest_session_duration_seconds",
+		Help: "Duration of a single ingest session's Run call, in seconds.",
+	})
+	ingestLedgersTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "horizon_ingest_ledgers_total",
+		Help: "Total number of ledgers successfully ingested.",
+	})
+	ingestErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "horizon_ingest_errors_total",
+		Help: "Total number of errors encountered while ingesting, labeled by stage.",
+	}, []string{"stage"})
+)
+
+// PrometheusCollectors returns the collectors that track ingestion health, so
+// callers can register them with a prometheus.Registerer of their choosing.
+func (i *System) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		ingestCoreLatestLedger,
+		ingestHistoryLatestLedger,
+		ingestLagLedgers,
+		ingestSessionDuration,
+		ingestLedgersTotal,
+		ingestErrorsTotal,
+	}
+}
+
+// MetricsHandler renders PrometheusCollectors in the text exposition format,
+// for mounting on the admin router so operators can alert on ingestion lag
+// and error-rate spikes instead of tailing logs.
+func (i *System) MetricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	for _, c := range i.PrometheusCollectors() {
+		registry.MustRegister(c)
+	}
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterAdminRoutes mounts MetricsHandler at /metrics on router. Called by
+// the `horizon serve` command's admin router setup (see cli/serve.go).
+func (i *System) RegisterAdminRoutes(router *mux.Router) {
+	router.Handle("/metrics", i.MetricsHandler()).Methods("GET")
+}
@@ -0,0 +1,203 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/openbankit/horizon/db2/history"
+	"github.com/openbankit/horizon/ingest/session"
+	"github.com/openbankit/horizon/log"
+)
+
+// DefaultParallelJobSize is the number of ledgers handed to a single worker
+// when no job size is supplied to ReingestRangeParallel.
+const DefaultParallelJobSize = int32(100000)
+
+// rangeJob describes a single [Start, End] sub-range to be reingested by a
+// worker.
+type rangeJob struct {
+	Start int32
+	End   int32
+}
+
+// rangeResult carries the outcome of reingesting a single rangeJob back to
+// the coordinating goroutine.
+type rangeResult struct {
+	Job      rangeJob
+	Ingested int
+	Err      error
+}
+
+// ErrRangeFailures is returned by ReingestRangeParallel when one or more
+// sub-ranges failed to reingest. Operators can retry just the listed ranges.
+type ErrRangeFailures struct {
+	Failures []rangeJob
+}
+
+func (e ErrRangeFailures) Error() string {
+	return fmt.Sprintf("%d sub-range(s) failed during parallel reingest: %v", len(e.Failures), e.Failures)
+}
+
+// ReingestRangeParallel splits [start, end] into sub-ranges of at most
+// jobSize ledgers and reingests them concurrently across workers worker
+// goroutines. Each worker uses its own cloned DB handles and account cache so
+// that no state is shared across goroutines.
+//
+// Trade aggregation and account cache rebuilds are deferred until every
+// worker has finished, since running them once per sub-range would race and
+// produce duplicate/conflicting rebuild errors.
+func (i *System) ReingestRangeParallel(start, end int32, workers int, jobSize int32) (int, error) {
+	return i.ReingestRangeParallelConfig(start, end, workers, jobSize, i.Config)
+}
+
+// ReingestRangeParallelConfig is like ReingestRangeParallel, but runs every
+// worker's session with cfg instead of the system's default Config.
+//
+// If the system's context is canceled while workers are still running and no
+// sub-range failed with another error, this returns context.Canceled rather
+// than ErrRangeFailures, so callers can tell a clean shutdown apart from
+// sub-ranges that genuinely failed to reingest. If any sub-range did fail
+// with another error, ErrRangeFailures is returned, listing only those
+// sub-ranges.
+func (i *System) ReingestRangeParallelConfig(start, end int32, workers int, jobSize int32, cfg Config) (int, error) {
+	i.wg.Add(1)
+	defer i.wg.Done()
+
+	err := i.updateLedgerState()
+	if err != nil {
+		return 0, err
+	}
+
+	if i.polling() && end > i.historySequence {
+		return 0, ErrReingestRangeConflict{Ledger: i.historySequence}
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	if jobSize < 1 {
+		jobSize = DefaultParallelJobSize
+	}
+
+	ctx := i.context()
+	jobs := make(chan rangeJob, workers)
+	results := make(chan rangeResult, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go i.reingestWorker(ctx, cfg, &wg, jobs, results)
+	}
+
+	go func() {
+		for s := start; s <= end; s += jobSize {
+			e := s + jobSize - 1
+			if e > end {
+				e = end
+			}
+			jobs <- rangeJob{Start: s, End: e}
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		totalIngested int
+		failed        []rangeJob
+		canceled      bool
+	)
+	for res := range results {
+		if res.Err == context.Canceled {
+			canceled = true
+			continue
+		}
+		if res.Err != nil {
+			log.WithField("start", res.Job.Start).
+				WithField("end", res.Job.End).
+				Errorf("parallel reingest sub-range failed: %s", res.Err)
+			failed = append(failed, res.Job)
+			continue
+		}
+		totalIngested += res.Ingested
+	}
+
+	if len(failed) > 0 {
+		return totalIngested, ErrRangeFailures{Failures: failed}
+	}
+
+	if canceled {
+		return totalIngested, context.Canceled
+	}
+
+	if err := i.rebuildAggregates(start, end, cfg); err != nil {
+		return totalIngested, err
+	}
+
+	return totalIngested, nil
+}
+
+// reingestWorker pulls sub-ranges off of jobs, reingests each one using a
+// dedicated session bound to cloned DB handles, and publishes the outcome on
+// results. It keeps draining jobs (rather than exiting early) so that a
+// failure in one sub-range does not abandon work already started by this
+// worker.
+func (i *System) reingestWorker(ctx context.Context, cfg Config, wg *sync.WaitGroup, jobs <-chan rangeJob, results chan<- rangeResult) {
+	defer wg.Done()
+
+	horizonDB := i.HorizonDB.Clone()
+	coreDB := i.CoreDB.Clone()
+	accountCache := i.HistoryAccountCache.Clone()
+
+	for job := range jobs {
+		if ctx.Err() != nil {
+			results <- rangeResult{Job: job, Err: ctx.Err()}
+			continue
+		}
+
+		hq := history.Q{Repo: horizonDB}
+		if _, err := hq.RemoveLedgerRange(job.Start, job.End); err != nil {
+			results <- rangeResult{Job: job, Err: err}
+			continue
+		}
+
+		is := session.NewSession(
+			ctx,
+			job.Start,
+			job.End,
+			horizonDB,
+			coreDB,
+			accountCache,
+			i.Metrics,
+			CurrentVersion,
+			cfg,
+		)
+		is.DeferAggregateRebuild = true
+
+		err := is.Run()
+		results <- rangeResult{Job: job, Ingested: is.Ingested, Err: err}
+	}
+}
+
+// rebuildAggregates runs the trade-aggregation and account-cache rebuild
+// steps once for the whole [start, end] range. It is safe to call after a
+// parallel reingest since every sub-range has finished writing by the time
+// it runs.
+func (i *System) rebuildAggregates(start, end int32, cfg Config) error {
+	is := session.NewSession(
+		i.context(),
+		start,
+		end,
+		i.HorizonDB,
+		i.CoreDB,
+		i.HistoryAccountCache,
+		i.Metrics,
+		CurrentVersion,
+		cfg,
+	)
+	return is.RebuildAggregates()
+}
@@ -0,0 +1,79 @@
+package session
+
+// ingestLedgerHeader writes the history_ledgers row for seq, pulling the
+// corresponding ledger header from CoreDB.
+func (s *Session) ingestLedgerHeader(seq int32) error {
+	_, err := s.HorizonDB.Exec(
+		"INSERT INTO history_ledgers (sequence, closed_at) "+
+			"SELECT ledgerseq, closetime FROM ledgerheaders WHERE ledgerseq = ?",
+		seq,
+	)
+	return err
+}
+
+// ingestTransactions writes history_transactions rows for every transaction
+// included in seq.
+func (s *Session) ingestTransactions(seq int32) error {
+	_, err := s.HorizonDB.Exec(
+		"INSERT INTO history_transactions (ledger_sequence, txid) "+
+			"SELECT ledgerseq, transactionid FROM txhistory WHERE ledgerseq = ?",
+		seq,
+	)
+	return err
+}
+
+// ingestOperations writes history_operations rows for every operation
+// contained in a transaction already ingested for seq. It must run after
+// ingestTransactions (it joins against the history_transactions rows
+// ingestTransactions just wrote) and before ingestEffects/updateAssetStats,
+// both of which read history_operations back out.
+func (s *Session) ingestOperations(seq int32) error {
+	_, err := s.HorizonDB.Exec(
+		"INSERT INTO history_operations (transaction_id, application_order, type, source_account, details) "+
+			"SELECT ht.id, txop.application_order, txop.type, txop.source_account, txop.details "+
+			"FROM txoperations txop "+
+			"JOIN history_transactions ht ON ht.txid = txop.transactionid "+
+			"WHERE txop.ledgerseq = ?",
+		seq,
+	)
+	return err
+}
+
+// ingestEffects writes history_effects rows derived from the operations
+// already ingested for seq. Callers skip this when Config.DisableEffects is
+// set.
+func (s *Session) ingestEffects(seq int32) error {
+	_, err := s.HorizonDB.Exec(
+		"INSERT INTO history_effects (history_operation_id, type) "+
+			"SELECT id, effect_type FROM history_operations WHERE transaction_id IN "+
+			"(SELECT id FROM history_transactions WHERE ledger_sequence = ?)",
+		seq,
+	)
+	return err
+}
+
+// updateAssetStats records the per-asset holder/amount deltas contributed by
+// seq into history_assets_stats_deltas, keyed by ledger sequence so that
+// RemoveLedgerRange can clear them before an overlapping reingest instead of
+// double-counting. Callers skip this when Config.DisableAssetStats is set.
+func (s *Session) updateAssetStats(seq int32) error {
+	_, err := s.HorizonDB.Exec(
+		"INSERT INTO history_assets_stats_deltas (ledger_sequence, asset, amount_delta, accounts_delta) "+
+			"SELECT ledger_sequence, asset, amount_delta, accounts_delta FROM history_operations "+
+			"WHERE transaction_id IN "+
+			"(SELECT id FROM history_transactions WHERE ledger_sequence = ?)",
+		seq,
+	)
+	return err
+}
+
+// updateCursor advances the horizon cursor on stellar-core to seq, so core
+// can prune ledgers horizon has already ingested. Callers skip this when
+// Config.SkipCursorUpdate is set.
+func (s *Session) updateCursor(seq int32) error {
+	_, err := s.CoreDB.Exec(
+		"UPDATE cursors SET cursor = ? WHERE id = 'HORIZON'",
+		seq,
+	)
+	return err
+}
@@ -0,0 +1,233 @@
+// Package session ingests a range of ledgers from stellar-core into
+// horizon's history database.
+package session
+
+import (
+	"context"
+
+	"github.com/openbankit/horizon/db"
+	"github.com/openbankit/horizon/log"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// AccountCache is the per-worker account-id cache a Session consults while
+// ingesting operations and effects. Clone returns an independent cache bound
+// to the same underlying lookup source, so concurrent sessions (e.g. the
+// parallel reingest workers) never share mutable cache state.
+type AccountCache interface {
+	Clone() AccountCache
+
+	// Rebuild recomputes any cached account data that depends on ledgers in
+	// [start, end], inclusive.
+	Rebuild(start, end int32) error
+}
+
+// Config controls which of the more expensive ingestion sub-processors a
+// session runs.
+type Config struct {
+	// DisableAssetStats skips recomputing asset holder/amount statistics.
+	DisableAssetStats bool
+
+	// DisableTradeAggregations skips rebuilding the trade aggregation
+	// buckets used to serve `/trade_aggregations`.
+	DisableTradeAggregations bool
+
+	// DisableEffects skips writing rows to history_effects.
+	DisableEffects bool
+
+	// SkipCursorUpdate leaves the horizon ingestion cursor on stellar-core
+	// untouched, which is useful when a session is only backfilling history
+	// that core has already moved past.
+	SkipCursorUpdate bool
+}
+
+// Session ingests ledgers [FirstLedger, LastLedger], inclusive, from CoreDB
+// into HorizonDB.
+type Session struct {
+	// Ctx is observed at every ledger boundary and before every DB call made
+	// while ingesting a ledger, so that canceling it stops the session in
+	// between ledgers instead of running the whole range to completion.
+	// RebuildAggregates checks it once, before its first DB call.
+	Ctx context.Context
+
+	FirstLedger int32
+	LastLedger  int32
+
+	HorizonDB *db.Repo
+	CoreDB    *db.Repo
+	Cache     AccountCache
+	Metrics   metrics.Registry
+
+	IngestVersion int
+	Config        Config
+
+	// DeferAggregateRebuild skips RebuildAggregates at the end of Run,
+	// letting a caller that ingests many sub-ranges concurrently (the
+	// parallel reingest worker pool) run it once after every worker
+	// finishes instead of racing to rebuild the same aggregates per
+	// sub-range.
+	DeferAggregateRebuild bool
+
+	// Ingested is the number of ledgers successfully ingested by Run.
+	Ingested int
+}
+
+// NewSession builds a Session that will ingest [first, last] using horizonDB
+// and coreDB.
+func NewSession(
+	ctx context.Context,
+	first, last int32,
+	horizonDB, coreDB *db.Repo,
+	cache AccountCache,
+	m metrics.Registry,
+	ingestVersion int,
+	cfg Config,
+) *Session {
+	return &Session{
+		Ctx:           ctx,
+		FirstLedger:   first,
+		LastLedger:    last,
+		HorizonDB:     horizonDB,
+		CoreDB:        coreDB,
+		Cache:         cache,
+		Metrics:       m,
+		IngestVersion: ingestVersion,
+		Config:        cfg,
+	}
+}
+
+// Run ingests every ledger in [FirstLedger, LastLedger]. It observes Ctx
+// cancellation at each ledger boundary and before each DB call it makes
+// while ingesting a ledger, and returns context.Canceled (rather than an
+// opaque wrapped error) the moment it notices Ctx is done, so callers can
+// distinguish a clean shutdown from a real ingestion failure.
+func (s *Session) Run() error {
+	for seq := s.FirstLedger; seq <= s.LastLedger; seq++ {
+		if err := s.Ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := s.ingestLedger(seq); err != nil {
+			return err
+		}
+
+		s.Ingested++
+	}
+
+	if s.DeferAggregateRebuild {
+		return nil
+	}
+
+	return s.RebuildAggregates()
+}
+
+// ingestLedger writes every history row for a single ledger sequence. It
+// assumes the caller has already cleared any existing history rows for seq
+// (e.g. via history.Q.RemoveLedgerRange over the whole range being
+// ingested) rather than clearing seq again here, since every ingest-package
+// caller that constructs a Session over a possibly-overlapping range already
+// does that range-level clear up front.
+func (s *Session) ingestLedger(seq int32) error {
+	if err := s.Ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.ingestLedgerHeader(seq); err != nil {
+		return err
+	}
+
+	if err := s.Ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.ingestTransactions(seq); err != nil {
+		return err
+	}
+
+	if err := s.Ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.ingestOperations(seq); err != nil {
+		return err
+	}
+
+	if s.Config.DisableEffects {
+		log.WithField("sequence", seq).Debug("ingest: skipping effects (DisableEffects)")
+	} else {
+		if err := s.Ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.ingestEffects(seq); err != nil {
+			return err
+		}
+	}
+
+	if s.Config.DisableAssetStats {
+		log.WithField("sequence", seq).Debug("ingest: skipping asset stats (DisableAssetStats)")
+	} else {
+		if err := s.Ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.updateAssetStats(seq); err != nil {
+			return err
+		}
+	}
+
+	if s.Config.SkipCursorUpdate {
+		log.WithField("sequence", seq).Debug("ingest: skipping cursor update (SkipCursorUpdate)")
+		return nil
+	}
+
+	if err := s.Ctx.Err(); err != nil {
+		return err
+	}
+	return s.updateCursor(seq)
+}
+
+// RebuildAggregates rebuilds the trade aggregation buckets and the account
+// cache over [FirstLedger, LastLedger]. It is safe to call once after a
+// parallel reingest, since every worker will have finished writing by then.
+func (s *Session) RebuildAggregates() error {
+	if s.Config.DisableTradeAggregations {
+		log.Debug("ingest: skipping trade aggregation rebuild (DisableTradeAggregations)")
+		return s.rebuildAccountCache()
+	}
+
+	if err := s.Ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := s.HorizonDB.Exec(
+		"DELETE FROM history_trade_aggregations WHERE ledger_closed_at IN "+
+			"(SELECT closed_at FROM history_ledgers WHERE sequence BETWEEN ? AND ?)",
+		s.FirstLedger,
+		s.LastLedger,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.HorizonDB.Exec(
+		"INSERT INTO history_trade_aggregations "+
+			"SELECT * FROM rebuild_trade_aggregations("+
+			"(SELECT closed_at FROM history_ledgers WHERE sequence = ?), "+
+			"(SELECT closed_at FROM history_ledgers WHERE sequence = ?))",
+		s.FirstLedger,
+		s.LastLedger,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.rebuildAccountCache()
+}
+
+// rebuildAccountCache refreshes the session's account cache for
+// [FirstLedger, LastLedger]. It runs regardless of
+// Config.DisableTradeAggregations, since the cache backs account lookups
+// used throughout ingestion, not just trade aggregation.
+func (s *Session) rebuildAccountCache() error {
+	if s.Cache == nil {
+		return nil
+	}
+
+	return s.Cache.Rebuild(s.FirstLedger, s.LastLedger)
+}
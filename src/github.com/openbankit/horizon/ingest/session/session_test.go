@@ -0,0 +1,103 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openbankit/horizon/ingest/session"
+	"github.com/openbankit/horizon/test"
+)
+
+// TestSessionRun exercises a single ledger end-to-end: seed stellar-core's
+// tables, run a Session over it, and assert that history_ledgers,
+// history_transactions and history_operations each got a row and that
+// stellar-core's cursor advanced to the ledger. history_effects and
+// history_assets_stats_deltas are not checked.
+func TestSessionRun(t *testing.T) {
+	tt := test.Start(t).Scenario("base")
+	defer tt.Finish()
+
+	tt.ResetHorizonDB()
+	tt.ResetCoreDB()
+
+	const seq = int32(1)
+
+	_, err := tt.CoreRepo().Exec(
+		"INSERT INTO ledgerheaders (ledgerseq, closetime) VALUES (?, NOW())", seq,
+	)
+	require.NoError(t, err)
+	_, err = tt.CoreRepo().Exec(
+		"INSERT INTO txhistory (ledgerseq, transactionid) VALUES (?, ?)", seq, 1,
+	)
+	require.NoError(t, err)
+	_, err = tt.CoreRepo().Exec(
+		"INSERT INTO txoperations (ledgerseq, transactionid, application_order, type, source_account, details) "+
+			"VALUES (?, ?, ?, ?, ?, ?)",
+		seq, 1, 1, "payment", "GTEST", "{}",
+	)
+	require.NoError(t, err)
+	_, err = tt.CoreRepo().Exec(
+		"INSERT INTO cursors (id, cursor) VALUES ('HORIZON', 0)",
+	)
+	require.NoError(t, err)
+
+	s := session.NewSession(
+		context.Background(),
+		seq, seq,
+		tt.HorizonRepo(), tt.CoreRepo(),
+		nil, nil, 1, session.Config{},
+	)
+	require.NoError(t, s.Run())
+	assert.Equal(t, 1, s.Ingested)
+
+	var ledgerCount, txCount, opCount int
+	require.NoError(t, tt.HorizonRepo().GetRaw(&ledgerCount, "SELECT COUNT(*) FROM history_ledgers WHERE sequence = ?", seq))
+	assert.Equal(t, 1, ledgerCount)
+
+	require.NoError(t, tt.HorizonRepo().GetRaw(&txCount, "SELECT COUNT(*) FROM history_transactions WHERE ledger_sequence = ?", seq))
+	assert.Equal(t, 1, txCount)
+
+	require.NoError(t, tt.HorizonRepo().GetRaw(&opCount, "SELECT COUNT(*) FROM history_operations ho "+
+		"JOIN history_transactions ht ON ht.id = ho.transaction_id WHERE ht.ledger_sequence = ?", seq))
+	assert.Equal(t, 1, opCount)
+
+	var cursor int32
+	require.NoError(t, tt.CoreRepo().GetRaw(&cursor, "SELECT cursor FROM cursors WHERE id = 'HORIZON'"))
+	assert.Equal(t, seq, cursor)
+}
+
+// TestSessionRunSkipCursorUpdate checks that Config.SkipCursorUpdate leaves
+// stellar-core's cursor untouched.
+func TestSessionRunSkipCursorUpdate(t *testing.T) {
+	tt := test.Start(t).Scenario("base")
+	defer tt.Finish()
+
+	tt.ResetHorizonDB()
+	tt.ResetCoreDB()
+
+	const seq = int32(1)
+
+	_, err := tt.CoreRepo().Exec(
+		"INSERT INTO ledgerheaders (ledgerseq, closetime) VALUES (?, NOW())", seq,
+	)
+	require.NoError(t, err)
+	_, err = tt.CoreRepo().Exec(
+		"INSERT INTO cursors (id, cursor) VALUES ('HORIZON', 0)",
+	)
+	require.NoError(t, err)
+
+	s := session.NewSession(
+		context.Background(),
+		seq, seq,
+		tt.HorizonRepo(), tt.CoreRepo(),
+		nil, nil, 1, session.Config{SkipCursorUpdate: true},
+	)
+	require.NoError(t, s.Run())
+
+	var cursor int32
+	require.NoError(t, tt.CoreRepo().GetRaw(&cursor, "SELECT cursor FROM cursors WHERE id = 'HORIZON'"))
+	assert.EqualValues(t, 0, cursor)
+}